@@ -3,8 +3,11 @@ package filter
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
 )
 
@@ -62,6 +65,212 @@ type StreamFilterManager interface {
 	OnDestroy()
 }
 
+// LocalReply carries the headers/data/trailers a filter wants to send instead
+// of letting the stream continue, as passed to StreamDecoderFilterCallbacks.SendLocalReply
+// and StreamEncoderFilterCallbacks.SendLocalReply.
+type LocalReply struct {
+	Status   int
+	Headers  types.HeaderMap
+	Data     types.IoBuffer
+	Trailers types.HeaderMap
+}
+
+// StreamDecoderFilterCallbacks is handed to a StreamReceiverFilter so it can
+// drive the receiver chain on its own, independently of whatever the sender
+// chain is doing. It is modeled on Envoy's Go HTTP filter decoder callbacks.
+type StreamDecoderFilterCallbacks interface {
+	// ContinueDecoding resumes the receiver filter chain from the filter that
+	// previously returned StreamFilterChainStop. It may be called from any
+	// goroutine and never touches the sender chain's cursor.
+	ContinueDecoding()
+
+	// SendLocalReply atomically stops both the receiver and the sender
+	// filter chains and replaces the stream's outcome with reply.
+	SendLocalReply(reply *LocalReply)
+}
+
+// StreamEncoderFilterCallbacks is the sender-chain counterpart of
+// StreamDecoderFilterCallbacks.
+type StreamEncoderFilterCallbacks interface {
+	// ContinueEncoding resumes the sender filter chain from the filter that
+	// previously returned StreamFilterChainStop. It may be called from any
+	// goroutine and never touches the receiver chain's cursor.
+	ContinueEncoding()
+
+	// SendLocalReply atomically stops both the receiver and the sender
+	// filter chains and replaces the stream's outcome with reply.
+	SendLocalReply(reply *LocalReply)
+}
+
+// DecoderFilterCallbacksSetter is implemented by a StreamReceiverFilter that
+// wants to drive its own continuation in full-duplex mode (see
+// DefaultStreamFilterManagerImpl.SetFullDuplex). The manager calls it once,
+// when the filter is registered.
+type DecoderFilterCallbacksSetter interface {
+	SetDecoderFilterCallbacks(cb StreamDecoderFilterCallbacks)
+}
+
+// EncoderFilterCallbacksSetter is the sender-chain counterpart of
+// DecoderFilterCallbacksSetter.
+type EncoderFilterCallbacksSetter interface {
+	SetEncoderFilterCallbacks(cb StreamEncoderFilterCallbacks)
+}
+
+// streamDirectionState is the resumable cursor for one direction (receiver or
+// sender) of a stream running in full-duplex mode: the saved arguments of the
+// call that paused, so Continue{Decoding,Encoding} can replay it later from a
+// different goroutine without needing the caller to remember anything.
+type streamDirectionState struct {
+	paused        bool
+	ctx           context.Context
+	phase         api.FilterPhase
+	headers       types.HeaderMap
+	data          types.IoBuffer
+	trailers      types.HeaderMap
+	statusHandler StreamFilterStatusHandler
+}
+
+// decoderFilterCallbacks is the concrete StreamDecoderFilterCallbacks handed
+// out by a manager.
+type decoderFilterCallbacks struct {
+	manager *DefaultStreamFilterManagerImpl
+}
+
+func (c *decoderFilterCallbacks) ContinueDecoding() {
+	c.manager.ContinueDecoding()
+}
+
+func (c *decoderFilterCallbacks) SendLocalReply(reply *LocalReply) {
+	c.manager.sendLocalReply(reply)
+}
+
+// encoderFilterCallbacks is the concrete StreamEncoderFilterCallbacks handed
+// out by a manager.
+type encoderFilterCallbacks struct {
+	manager *DefaultStreamFilterManagerImpl
+}
+
+func (c *encoderFilterCallbacks) ContinueEncoding() {
+	c.manager.ContinueEncoding()
+}
+
+func (c *encoderFilterCallbacks) SendLocalReply(reply *LocalReply) {
+	c.manager.sendLocalReply(reply)
+}
+
+// PanicHandler decides what happens after a filter invocation panics. name
+// identifies the filter that panicked (see filterTypeName) and recovered is
+// the value returned by recover(). It returns how the owning chain should
+// proceed and, optionally, a LocalReply to send instead of letting the
+// stream continue.
+type PanicHandler func(ctx context.Context, name string, phase api.FilterPhase, recovered interface{}) (StreamFilterChainStatus, *LocalReply)
+
+// DefaultPanicHandler is used when neither the filter nor its manager
+// configures a PanicHandler. It resets the chain and sends a plain 500.
+func DefaultPanicHandler(ctx context.Context, name string, phase api.FilterPhase, recovered interface{}) (StreamFilterChainStatus, *LocalReply) {
+	return StreamFilterChainReset, &LocalReply{Status: 500}
+}
+
+// panicHandlerProvider is implemented by a StreamReceiverFilterWithPhase or
+// StreamSenderFilterWithPhase constructed with WithPanicHandler.
+type panicHandlerProvider interface {
+	PanicHandler() PanicHandler
+}
+
+// panicHandlerSetter lets a single WithPanicHandler option work for both
+// NewStreamReceiverFilterWithPhaseImpl and NewStreamSenderFilterWithPhaseImpl.
+type panicHandlerSetter interface {
+	setPanicHandler(h PanicHandler)
+}
+
+// FilterWithPhaseOption configures a StreamReceiverFilterWithPhaseImpl or a
+// StreamSenderFilterWithPhaseImpl at construction time.
+type FilterWithPhaseOption func(panicHandlerSetter)
+
+// WithPanicHandler overrides, for this filter only, the PanicHandler that
+// runs when the filter panics; it otherwise falls back to the manager's
+// default (see DefaultStreamFilterManagerImpl.SetDefaultPanicHandler).
+func WithPanicHandler(h PanicHandler) FilterWithPhaseOption {
+	return func(s panicHandlerSetter) {
+		s.setPanicHandler(h)
+	}
+}
+
+// FilterPanicCounter receives one Inc call per recovered filter panic, with
+// the filter and phase labels of mosn_filter_panics_total{filter=...,phase=...}.
+// The package defaults to an in-memory counter that nothing outside this
+// package can see; call SetFilterPanicCounter during startup to register the
+// real stats backend (e.g. pkg/metrics) that exposes it to operators.
+type FilterPanicCounter interface {
+	Inc(filter, phase string)
+}
+
+// FilterPanicCounterFunc adapts a plain function to a FilterPanicCounter, for
+// backends that only need the increment call, e.g. a closure over a
+// prometheus CounterVec.
+type FilterPanicCounterFunc func(filter, phase string)
+
+// Inc calls f.
+func (f FilterPanicCounterFunc) Inc(filter, phase string) {
+	f(filter, phase)
+}
+
+// filterPanicsTotal backs the mosn_filter_panics_total{filter=...,phase=...}
+// counter. It defaults to an in-memory panicCounter; see SetFilterPanicCounter.
+var filterPanicsTotal FilterPanicCounter = newPanicCounter()
+
+// SetFilterPanicCounter overrides the FilterPanicCounter backing
+// mosn_filter_panics_total, so a real stats backend sees every recovered
+// filter panic instead of the invisible in-memory default.
+func SetFilterPanicCounter(c FilterPanicCounter) {
+	filterPanicsTotal = c
+}
+
+type panicCounter struct {
+	mu     sync.Mutex
+	counts map[[2]string]int64
+}
+
+func newPanicCounter() *panicCounter {
+	return &panicCounter{counts: make(map[[2]string]int64)}
+}
+
+func (c *panicCounter) Inc(filter, phase string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[[2]string{filter, phase}]++
+}
+
+// Get returns the current mosn_filter_panics_total value for filter/phase.
+// Only meaningful while the default in-memory panicCounter is installed.
+func (c *panicCounter) Get(filter, phase string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[[2]string{filter, phase}]
+}
+
+// filterTypeName returns a stable, human-readable identifier for filter,
+// used for logging and for the filter label on mosn_filter_panics_total.
+func filterTypeName(filter interface{}) string {
+	return fmt.Sprintf("%T", filter)
+}
+
+// namedFilter is implemented by a StreamReceiverFilterWithPhaseImpl or
+// StreamSenderFilterWithPhaseImpl, which cache filterTypeName at
+// construction time.
+type namedFilter interface {
+	Name() string
+}
+
+// filterDisplayName returns filter's cached Name(), falling back to
+// filterTypeName for filters that don't implement namedFilter.
+func filterDisplayName(filter interface{}) string {
+	if n, ok := filter.(namedFilter); ok {
+		return n.Name()
+	}
+	return filterTypeName(filter)
+}
+
 // StreamReceiverFilterWithPhase combines the StreamReceiverFilter with its Phase.
 type StreamReceiverFilterWithPhase interface {
 	api.StreamReceiverFilter
@@ -71,16 +280,23 @@ type StreamReceiverFilterWithPhase interface {
 // StreamReceiverFilterWithPhaseImpl is the default implementation of StreamReceiverFilterWithPhase.
 type StreamReceiverFilterWithPhaseImpl struct {
 	api.StreamReceiverFilter
-	phase api.FilterPhase
+	phase        api.FilterPhase
+	name         string
+	panicHandler PanicHandler
 }
 
 // NewStreamReceiverFilterWithPhaseImpl returns a StreamReceiverFilterWithPhaseImpl struct..
 func NewStreamReceiverFilterWithPhaseImpl(
-	f api.StreamReceiverFilter, p api.FilterPhase) *StreamReceiverFilterWithPhaseImpl {
-	return &StreamReceiverFilterWithPhaseImpl{
+	f api.StreamReceiverFilter, p api.FilterPhase, opts ...FilterWithPhaseOption) *StreamReceiverFilterWithPhaseImpl {
+	s := &StreamReceiverFilterWithPhaseImpl{
 		StreamReceiverFilter: f,
 		phase:                p,
+		name:                 filterTypeName(f),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // ValidatePhase checks the current phase.
@@ -88,6 +304,21 @@ func (s *StreamReceiverFilterWithPhaseImpl) ValidatePhase(phase api.FilterPhase)
 	return s.phase == phase
 }
 
+// PanicHandler returns the PanicHandler set via WithPanicHandler, or nil.
+func (s *StreamReceiverFilterWithPhaseImpl) PanicHandler() PanicHandler {
+	return s.panicHandler
+}
+
+// Name returns the filter's identifier, as used for logging and for the
+// filter label on mosn_filter_panics_total.
+func (s *StreamReceiverFilterWithPhaseImpl) Name() string {
+	return s.name
+}
+
+func (s *StreamReceiverFilterWithPhaseImpl) setPanicHandler(h PanicHandler) {
+	s.panicHandler = h
+}
+
 // StreamSenderFilterWithPhase combines the StreamSenderFilter which its Phase.
 type StreamSenderFilterWithPhase interface {
 	api.StreamSenderFilter
@@ -97,15 +328,23 @@ type StreamSenderFilterWithPhase interface {
 // StreamSenderFilterWithPhaseImpl is default implementation of StreamSenderFilterWithPhase.
 type StreamSenderFilterWithPhaseImpl struct {
 	api.StreamSenderFilter
-	phase api.FilterPhase
+	phase        api.FilterPhase
+	name         string
+	panicHandler PanicHandler
 }
 
 // NewStreamSenderFilterWithPhaseImpl returns a new StreamSenderFilterWithPhaseImpl.
-func NewStreamSenderFilterWithPhaseImpl(f api.StreamSenderFilter, p api.FilterPhase) *StreamSenderFilterWithPhaseImpl {
-	return &StreamSenderFilterWithPhaseImpl{
+func NewStreamSenderFilterWithPhaseImpl(
+	f api.StreamSenderFilter, p api.FilterPhase, opts ...FilterWithPhaseOption) *StreamSenderFilterWithPhaseImpl {
+	s := &StreamSenderFilterWithPhaseImpl{
 		StreamSenderFilter: f,
 		phase:              p,
+		name:               filterTypeName(f),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // ValidatePhase checks the current phase.
@@ -113,6 +352,21 @@ func (s *StreamSenderFilterWithPhaseImpl) ValidatePhase(phase api.FilterPhase) b
 	return true
 }
 
+// PanicHandler returns the PanicHandler set via WithPanicHandler, or nil.
+func (s *StreamSenderFilterWithPhaseImpl) PanicHandler() PanicHandler {
+	return s.panicHandler
+}
+
+// Name returns the filter's identifier, as used for logging and for the
+// filter label on mosn_filter_panics_total.
+func (s *StreamSenderFilterWithPhaseImpl) Name() string {
+	return s.name
+}
+
+func (s *StreamSenderFilterWithPhaseImpl) setPanicHandler(h PanicHandler) {
+	s.panicHandler = h
+}
+
 // DefaultStreamFilterManagerImpl is default implementation of the StreamFilterManager.
 type DefaultStreamFilterManagerImpl struct {
 	senderFilters      []StreamSenderFilterWithPhase
@@ -122,6 +376,75 @@ type DefaultStreamFilterManagerImpl struct {
 	receiverFiltersIndex int
 
 	streamAccessLogs []api.AccessLog
+
+	// fullDuplex, once enabled via SetFullDuplex, lets RunReceiverFilter and
+	// RunSenderFilter be called concurrently: a filter that pauses its own
+	// direction with StreamFilterChainStop no longer blocks the other chain.
+	// It defaults to false, so filters written against the original
+	// half-duplex behavior keep working unchanged.
+	fullDuplex bool
+
+	recvState streamDirectionState
+	sendState streamDirectionState
+
+	// defaultPanicHandler runs when a filter panics and doesn't configure its
+	// own PanicHandler via WithPanicHandler. Falls back to DefaultPanicHandler
+	// when unset.
+	defaultPanicHandler PanicHandler
+
+	mutex          sync.Mutex
+	localReplySent bool
+	localReply     *LocalReply
+}
+
+// StreamFilterManagerConfig configures a DefaultStreamFilterManagerImpl at
+// construction time. A StreamFilterChainFactory reads its own config (e.g.
+// from the listener's filter chain config) and passes the result here, so
+// operators opt a filter chain into full-duplex mode the same way they
+// configure everything else about it, without touching filter code.
+type StreamFilterManagerConfig struct {
+	// FullDuplex enables full-duplex stream filter execution (see
+	// DefaultStreamFilterManagerImpl.SetFullDuplex). Defaults to false, so
+	// filters written against the original half-duplex behavior keep
+	// working unchanged.
+	FullDuplex bool `json:"full_duplex,omitempty"`
+}
+
+// NewDefaultStreamFilterManagerImpl returns a DefaultStreamFilterManagerImpl
+// configured from cfg.
+func NewDefaultStreamFilterManagerImpl(cfg StreamFilterManagerConfig) *DefaultStreamFilterManagerImpl {
+	return &DefaultStreamFilterManagerImpl{
+		fullDuplex: cfg.FullDuplex,
+	}
+}
+
+// SetFullDuplex toggles full-duplex filter execution for this manager. Most
+// callers should instead opt in through StreamFilterManagerConfig at
+// construction time; this exists for callers that need to flip the mode on
+// an already-built manager.
+func (d *DefaultStreamFilterManagerImpl) SetFullDuplex(enabled bool) {
+	d.fullDuplex = enabled
+}
+
+// SetDefaultPanicHandler configures the PanicHandler used for filters that
+// don't set their own via WithPanicHandler. Leave unset to use
+// DefaultPanicHandler.
+func (d *DefaultStreamFilterManagerImpl) SetDefaultPanicHandler(h PanicHandler) {
+	d.defaultPanicHandler = h
+}
+
+// panicHandlerFor picks the PanicHandler that should run when filter panics:
+// the filter's own, else the manager's default, else DefaultPanicHandler.
+func (d *DefaultStreamFilterManagerImpl) panicHandlerFor(filter interface{}) PanicHandler {
+	if p, ok := filter.(panicHandlerProvider); ok {
+		if h := p.PanicHandler(); h != nil {
+			return h
+		}
+	}
+	if d.defaultPanicHandler != nil {
+		return d.defaultPanicHandler
+	}
+	return DefaultPanicHandler
 }
 
 // AddStreamSenderFilter registers senderFilters.
@@ -132,6 +455,28 @@ func (d *DefaultStreamFilterManagerImpl) AddStreamSenderFilter(filter api.Stream
 
 func (d *DefaultStreamFilterManagerImpl) AddStreamSenderFilterWithPhase(filter StreamSenderFilterWithPhase) {
 	d.senderFilters = append(d.senderFilters, filter)
+
+	if setter, ok := encoderFilterCallbacksSetter(filter); ok {
+		setter.SetEncoderFilterCallbacks(&encoderFilterCallbacks{manager: d})
+	}
+}
+
+// encoderFilterCallbacksSetter returns the EncoderFilterCallbacksSetter that
+// should be wired up for filter. A *StreamSenderFilterWithPhaseImpl embeds
+// api.StreamSenderFilter as an interface field, which only promotes that
+// interface's own method set, so the wrapper itself never implements
+// EncoderFilterCallbacksSetter even when the filter it wraps does: look
+// through the wrapper to the filter underneath.
+func encoderFilterCallbacksSetter(filter StreamSenderFilterWithPhase) (EncoderFilterCallbacksSetter, bool) {
+	if setter, ok := filter.(EncoderFilterCallbacksSetter); ok {
+		return setter, true
+	}
+	if wrapped, ok := filter.(*StreamSenderFilterWithPhaseImpl); ok {
+		if setter, ok := wrapped.StreamSenderFilter.(EncoderFilterCallbacksSetter); ok {
+			return setter, true
+		}
+	}
+	return nil, false
 }
 
 // AddStreamReceiverFilter registers receiver filters.
@@ -142,6 +487,28 @@ func (d *DefaultStreamFilterManagerImpl) AddStreamReceiverFilter(filter api.Stre
 
 func (d *DefaultStreamFilterManagerImpl) AddStreamReceiverFilterWithPhase(filter StreamReceiverFilterWithPhase) {
 	d.receiverFilters = append(d.receiverFilters, filter)
+
+	if setter, ok := decoderFilterCallbacksSetter(filter); ok {
+		setter.SetDecoderFilterCallbacks(&decoderFilterCallbacks{manager: d})
+	}
+}
+
+// decoderFilterCallbacksSetter returns the DecoderFilterCallbacksSetter that
+// should be wired up for filter. A *StreamReceiverFilterWithPhaseImpl embeds
+// api.StreamReceiverFilter as an interface field, which only promotes that
+// interface's own method set, so the wrapper itself never implements
+// DecoderFilterCallbacksSetter even when the filter it wraps does: look
+// through the wrapper to the filter underneath.
+func decoderFilterCallbacksSetter(filter StreamReceiverFilterWithPhase) (DecoderFilterCallbacksSetter, bool) {
+	if setter, ok := filter.(DecoderFilterCallbacksSetter); ok {
+		return setter, true
+	}
+	if wrapped, ok := filter.(*StreamReceiverFilterWithPhaseImpl); ok {
+		if setter, ok := wrapped.StreamReceiverFilter.(DecoderFilterCallbacksSetter); ok {
+			return setter, true
+		}
+	}
+	return nil, false
 }
 
 // AddStreamAccessLog registers access logger.
@@ -149,7 +516,13 @@ func (d *DefaultStreamFilterManagerImpl) AddStreamAccessLog(accessLog api.Access
 	d.streamAccessLogs = append(d.streamAccessLogs, accessLog)
 }
 
-// RunReceiverFilter invokes the receiver filter chain.
+// RunReceiverFilter invokes the receiver filter chain. In full-duplex mode
+// (see SetFullDuplex) it only ever touches receiver-side state, so it is safe
+// to call concurrently with RunSenderFilter: every read or write of
+// receiverFiltersIndex (including the ones SendLocalReply makes from the
+// sender goroutine) goes through d.mutex. The mutex is never held across a
+// filter invocation, only around the index bookkeeping before and after it.
+// A filter that panics is recovered: see invokeReceiverFilter.
 func (d *DefaultStreamFilterManagerImpl) RunReceiverFilter(ctx context.Context, phase api.FilterPhase,
 	headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap,
 	statusHandler StreamFilterStatusHandler) (filterStatus api.StreamFilterStatus) {
@@ -159,34 +532,54 @@ func (d *DefaultStreamFilterManagerImpl) RunReceiverFilter(ctx context.Context,
 
 	filterStatus = api.StreamFilterContinue
 
-	for ; d.receiverFiltersIndex < len(d.receiverFilters); d.receiverFiltersIndex++ {
-		filter := d.receiverFilters[d.receiverFiltersIndex]
+	for {
+		idx, ok := d.nextReceiverFilterIndex()
+		if !ok {
+			return
+		}
+
+		filter := d.receiverFilters[idx]
 		if !filter.ValidatePhase(phase) {
+			d.advanceReceiverFiltersIndex(idx + 1)
 			continue
 		}
 
-		filterStatus = filter.OnReceive(ctx, headers, data, trailers)
+		status, chainStatus, reply, panicked := d.invokeReceiverFilter(ctx, phase, headers, data, trailers, filter, statusHandler)
+		filterStatus = status
+		if panicked && reply != nil {
+			// The chosen policy is terminating the stream: halt both chains
+			// so neither runs the remaining filters. Every registered
+			// filter, including this one and the ones downstream of it,
+			// still gets exactly one OnDestroy() call from the stream's
+			// bookend OnDestroy() once it finishes.
+			d.sendLocalReply(reply)
+		}
 
-		chainStatus := statusHandler(filterStatus)
 		switch chainStatus {
 		case StreamFilterChainContinue:
+			d.advanceReceiverFiltersIndex(idx + 1)
 			continue
 		case StreamFilterChainStop:
+			if d.fullDuplex {
+				d.pauseReceiving(ctx, phase, headers, data, trailers, statusHandler)
+			}
 			return
 		case StreamFilterChainReset:
-			d.receiverFiltersIndex = 0
+			d.resetReceiverFiltersIndex()
 			return
 		default:
+			d.advanceReceiverFiltersIndex(idx + 1)
 			continue
 		}
 	}
-
-	d.receiverFiltersIndex = 0
-
-	return
 }
 
-// RunSenderFilter invokes the sender filter chain.
+// RunSenderFilter invokes the sender filter chain. In full-duplex mode (see
+// SetFullDuplex) it only ever touches sender-side state, so it is safe to
+// call concurrently with RunReceiverFilter: every read or write of
+// senderFiltersIndex (including the ones SendLocalReply makes from the
+// receiver goroutine) goes through d.mutex, never held across a filter
+// invocation. A filter that panics is recovered: see invokeSenderFilter.
 func (d *DefaultStreamFilterManagerImpl) RunSenderFilter(ctx context.Context, phase api.FilterPhase,
 	headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap,
 	statusHandler StreamFilterStatusHandler) (filterStatus api.StreamFilterStatus) {
@@ -196,33 +589,270 @@ func (d *DefaultStreamFilterManagerImpl) RunSenderFilter(ctx context.Context, ph
 
 	filterStatus = api.StreamFilterContinue
 
-	for ; d.senderFiltersIndex < len(d.senderFilters); d.senderFiltersIndex++ {
-		filter := d.senderFilters[d.senderFiltersIndex]
+	for {
+		idx, ok := d.nextSenderFilterIndex()
+		if !ok {
+			return
+		}
+
+		filter := d.senderFilters[idx]
 		if !filter.ValidatePhase(phase) {
+			d.advanceSenderFiltersIndex(idx + 1)
 			continue
 		}
 
-		filterStatus = filter.Append(ctx, headers, data, trailers)
+		status, chainStatus, reply, panicked := d.invokeSenderFilter(ctx, phase, headers, data, trailers, filter, statusHandler)
+		filterStatus = status
+		if panicked && reply != nil {
+			// The chosen policy is terminating the stream: halt both chains
+			// so neither runs the remaining filters. Every registered
+			// filter, including this one and the ones downstream of it,
+			// still gets exactly one OnDestroy() call from the stream's
+			// bookend OnDestroy() once it finishes.
+			d.sendLocalReply(reply)
+		}
 
-		chainStatus := statusHandler(filterStatus)
 		switch chainStatus {
 		case StreamFilterChainContinue:
+			d.advanceSenderFiltersIndex(idx + 1)
 			continue
 		case StreamFilterChainStop:
+			if d.fullDuplex {
+				d.pauseSending(ctx, phase, headers, data, trailers, statusHandler)
+			}
 			return
 		case StreamFilterChainReset:
-			d.receiverFiltersIndex = 0
+			d.resetSenderFiltersIndex()
 			return
 		default:
+			d.advanceSenderFiltersIndex(idx + 1)
 			continue
 		}
 	}
+}
 
-	d.senderFiltersIndex = 0
+// nextReceiverFilterIndex returns the receiver filter index to run next,
+// under d.mutex, or ok=false if the chain is done for this call (exhausted
+// or a local reply already short-circuited it). Reaching the end of
+// receiverFilters without a local reply rewinds receiverFiltersIndex to 0,
+// so the next RunReceiverFilter call (e.g. the data phase after headers)
+// starts from the first filter again instead of finding the chain stuck at
+// len(receiverFilters) forever. A local reply leaves the index untouched:
+// it means the chain is done for the whole stream, not just this call.
+func (d *DefaultStreamFilterManagerImpl) nextReceiverFilterIndex() (idx int, ok bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.localReplySent {
+		return 0, false
+	}
+	if d.receiverFiltersIndex >= len(d.receiverFilters) {
+		d.receiverFiltersIndex = 0
+		return 0, false
+	}
+	return d.receiverFiltersIndex, true
+}
+
+// nextSenderFilterIndex is the sender-chain counterpart of
+// nextReceiverFilterIndex.
+func (d *DefaultStreamFilterManagerImpl) nextSenderFilterIndex() (idx int, ok bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.localReplySent {
+		return 0, false
+	}
+	if d.senderFiltersIndex >= len(d.senderFilters) {
+		d.senderFiltersIndex = 0
+		return 0, false
+	}
+	return d.senderFiltersIndex, true
+}
+
+// advanceReceiverFiltersIndex moves receiverFiltersIndex to at least next,
+// under d.mutex. It never moves the index backwards, so it can't undo a
+// concurrent SendLocalReply that already pushed the index past the end.
+func (d *DefaultStreamFilterManagerImpl) advanceReceiverFiltersIndex(next int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
 
+	if next > d.receiverFiltersIndex {
+		d.receiverFiltersIndex = next
+	}
+}
+
+// advanceSenderFiltersIndex is the sender-chain counterpart of
+// advanceReceiverFiltersIndex.
+func (d *DefaultStreamFilterManagerImpl) advanceSenderFiltersIndex(next int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if next > d.senderFiltersIndex {
+		d.senderFiltersIndex = next
+	}
+}
+
+// resetReceiverFiltersIndex rewinds the receiver chain to its first filter,
+// under d.mutex, unless a concurrent SendLocalReply already ended the chain.
+func (d *DefaultStreamFilterManagerImpl) resetReceiverFiltersIndex() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if !d.localReplySent {
+		d.receiverFiltersIndex = 0
+	}
+}
+
+// resetSenderFiltersIndex is the sender-chain counterpart of
+// resetReceiverFiltersIndex.
+func (d *DefaultStreamFilterManagerImpl) resetSenderFiltersIndex() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if !d.localReplySent {
+		d.senderFiltersIndex = 0
+	}
+}
+
+// invokeReceiverFilter calls filter.OnReceive, recovering a panic so it
+// can't kill the connection goroutine. On a normal return, chainStatus comes
+// from statusHandler as usual. On a recovered panic, chainStatus and reply
+// instead come from whichever PanicHandler applies to filter (see
+// panicHandlerFor), and the panic is logged with stream context and filter
+// name before filterPanicsTotal is incremented.
+func (d *DefaultStreamFilterManagerImpl) invokeReceiverFilter(ctx context.Context, phase api.FilterPhase,
+	headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap,
+	filter StreamReceiverFilterWithPhase, statusHandler StreamFilterStatusHandler) (
+	status api.StreamFilterStatus, chainStatus StreamFilterChainStatus, reply *LocalReply, panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+			name := filterDisplayName(filter)
+			log.Proxy.Errorf(ctx, "stream filter %s panic in receiver phase %v: %v", name, phase, r)
+			filterPanicsTotal.Inc(name, fmt.Sprintf("%v", phase))
+			chainStatus, reply = d.panicHandlerFor(filter)(ctx, name, phase, r)
+		}
+	}()
+
+	status = filter.OnReceive(ctx, headers, data, trailers)
+	chainStatus = statusHandler(status)
 	return
 }
 
+// invokeSenderFilter is the sender-chain counterpart of invokeReceiverFilter.
+func (d *DefaultStreamFilterManagerImpl) invokeSenderFilter(ctx context.Context, phase api.FilterPhase,
+	headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap,
+	filter StreamSenderFilterWithPhase, statusHandler StreamFilterStatusHandler) (
+	status api.StreamFilterStatus, chainStatus StreamFilterChainStatus, reply *LocalReply, panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+			name := filterDisplayName(filter)
+			log.Proxy.Errorf(ctx, "stream filter %s panic in sender phase %v: %v", name, phase, r)
+			filterPanicsTotal.Inc(name, fmt.Sprintf("%v", phase))
+			chainStatus, reply = d.panicHandlerFor(filter)(ctx, name, phase, r)
+		}
+	}()
+
+	status = filter.Append(ctx, headers, data, trailers)
+	chainStatus = statusHandler(status)
+	return
+}
+
+// pauseReceiving saves the receiver chain's arguments, including the caller's
+// statusHandler, so ContinueDecoding can resume it later with the exact same
+// resume semantics, possibly from another goroutine.
+func (d *DefaultStreamFilterManagerImpl) pauseReceiving(ctx context.Context, phase api.FilterPhase,
+	headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap, statusHandler StreamFilterStatusHandler) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.recvState = streamDirectionState{
+		paused:        true,
+		ctx:           ctx,
+		phase:         phase,
+		headers:       headers,
+		data:          data,
+		trailers:      trailers,
+		statusHandler: statusHandler,
+	}
+}
+
+// pauseSending saves the sender chain's arguments, including the caller's
+// statusHandler, so ContinueEncoding can resume it later with the exact same
+// resume semantics, possibly from another goroutine.
+func (d *DefaultStreamFilterManagerImpl) pauseSending(ctx context.Context, phase api.FilterPhase,
+	headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap, statusHandler StreamFilterStatusHandler) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.sendState = streamDirectionState{
+		paused:        true,
+		ctx:           ctx,
+		phase:         phase,
+		headers:       headers,
+		data:          data,
+		trailers:      trailers,
+		statusHandler: statusHandler,
+	}
+}
+
+// ContinueDecoding resumes the receiver filter chain from the filter that
+// called Stop, replaying the statusHandler from the original RunReceiverFilter
+// call and without touching the sender chain's index. It is a no-op if the
+// receiver chain isn't currently paused.
+func (d *DefaultStreamFilterManagerImpl) ContinueDecoding() {
+	d.mutex.Lock()
+	state := d.recvState
+	if !state.paused {
+		d.mutex.Unlock()
+		return
+	}
+	d.recvState.paused = false
+	d.mutex.Unlock()
+
+	d.RunReceiverFilter(state.ctx, state.phase, state.headers, state.data, state.trailers, state.statusHandler)
+}
+
+// ContinueEncoding resumes the sender filter chain from the filter that
+// called Stop, replaying the statusHandler from the original RunSenderFilter
+// call and without touching the receiver chain's index. It is a no-op if the
+// sender chain isn't currently paused.
+func (d *DefaultStreamFilterManagerImpl) ContinueEncoding() {
+	d.mutex.Lock()
+	state := d.sendState
+	if !state.paused {
+		d.mutex.Unlock()
+		return
+	}
+	d.sendState.paused = false
+	d.mutex.Unlock()
+
+	d.RunSenderFilter(state.ctx, state.phase, state.headers, state.data, state.trailers, state.statusHandler)
+}
+
+// sendLocalReply atomically stops both filter chains from advancing any
+// further and records reply, so whichever chain calls RunReceiverFilter or
+// RunSenderFilter next returns immediately.
+func (d *DefaultStreamFilterManagerImpl) sendLocalReply(reply *LocalReply) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.localReplySent = true
+	d.localReply = reply
+	d.receiverFiltersIndex = len(d.receiverFilters)
+	d.senderFiltersIndex = len(d.senderFilters)
+}
+
+// LocalReply returns the reply recorded by SendLocalReply, or nil if no
+// filter has sent one.
+func (d *DefaultStreamFilterManagerImpl) LocalReply() *LocalReply {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.localReply
+}
+
 // Log invokes all access loggers.
 func (d *DefaultStreamFilterManagerImpl) Log(ctx context.Context,
 	reqHeaders api.HeaderMap, respHeaders api.HeaderMap, requestInfo api.RequestInfo) {
@@ -0,0 +1,199 @@
+package filter
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+// pausingReceiverFilter returns StreamFilterStop on its first OnReceive call
+// and StreamFilterContinue on every call after that, so RunReceiverFilter
+// pauses once and then drains normally once resumed.
+type pausingReceiverFilter struct {
+	api.StreamReceiverFilter
+	calls int32
+	cb    StreamDecoderFilterCallbacks
+}
+
+func (f *pausingReceiverFilter) OnReceive(ctx context.Context, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) api.StreamFilterStatus {
+	if atomic.AddInt32(&f.calls, 1) == 1 {
+		return api.StreamFilterStop
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *pausingReceiverFilter) OnDestroy() {}
+
+func (f *pausingReceiverFilter) SetDecoderFilterCallbacks(cb StreamDecoderFilterCallbacks) {
+	f.cb = cb
+}
+
+// pausingSenderFilter is the sender-chain counterpart of pausingReceiverFilter.
+type pausingSenderFilter struct {
+	api.StreamSenderFilter
+	calls int32
+	cb    StreamEncoderFilterCallbacks
+}
+
+func (f *pausingSenderFilter) Append(ctx context.Context, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) api.StreamFilterStatus {
+	if atomic.AddInt32(&f.calls, 1) == 1 {
+		return api.StreamFilterStop
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *pausingSenderFilter) OnDestroy() {}
+
+func (f *pausingSenderFilter) SetEncoderFilterCallbacks(cb StreamEncoderFilterCallbacks) {
+	f.cb = cb
+}
+
+// stopOnStopHandler is a StreamFilterStatusHandler that actually honors
+// StreamFilterStop (DefaultStreamFilterStatusHandler maps it to Reset), so
+// tests can exercise the pause/resume path.
+func stopOnStopHandler(status api.StreamFilterStatus) StreamFilterChainStatus {
+	if status == api.StreamFilterStop {
+		return StreamFilterChainStop
+	}
+	return StreamFilterChainContinue
+}
+
+func TestNewDefaultStreamFilterManagerImpl_FullDuplexConfig(t *testing.T) {
+	if m := NewDefaultStreamFilterManagerImpl(StreamFilterManagerConfig{FullDuplex: true}); !m.fullDuplex {
+		t.Fatal("expected fullDuplex to be true when StreamFilterManagerConfig.FullDuplex is true")
+	}
+	if m := NewDefaultStreamFilterManagerImpl(StreamFilterManagerConfig{}); m.fullDuplex {
+		t.Fatal("expected fullDuplex to default to false")
+	}
+}
+
+// TestContinueDecodingEncoding_Concurrent drives ContinueDecoding and
+// ContinueEncoding from separate goroutines at the same time, so -race can
+// catch a regression back to the shared index/statusHandler baseline had.
+func TestContinueDecodingEncoding_Concurrent(t *testing.T) {
+	m := NewDefaultStreamFilterManagerImpl(StreamFilterManagerConfig{FullDuplex: true})
+
+	recv := &pausingReceiverFilter{}
+	m.AddStreamReceiverFilterWithPhase(NewStreamReceiverFilterWithPhaseImpl(recv, UndefinedFilterPhase))
+
+	send := &pausingSenderFilter{}
+	m.AddStreamSenderFilterWithPhase(NewStreamSenderFilterWithPhaseImpl(send, UndefinedFilterPhase))
+
+	ctx := context.Background()
+	m.RunReceiverFilter(ctx, UndefinedFilterPhase, nil, nil, nil, stopOnStopHandler)
+	m.RunSenderFilter(ctx, UndefinedFilterPhase, nil, nil, nil, stopOnStopHandler)
+
+	if recv.cb == nil || send.cb == nil {
+		t.Fatal("expected both callbacks to be wired by AddStream*FilterWithPhase")
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		recv.cb.ContinueDecoding()
+	}()
+	go func() {
+		defer wg.Done()
+		send.cb.ContinueEncoding()
+	}()
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&recv.calls); got != 2 {
+		t.Fatalf("receiver filter called %d times, want 2", got)
+	}
+	if got := atomic.LoadInt32(&send.calls); got != 2 {
+		t.Fatalf("sender filter called %d times, want 2", got)
+	}
+}
+
+// continuingReceiverFilter always returns StreamFilterContinue, counting how
+// many times OnReceive ran.
+type continuingReceiverFilter struct {
+	api.StreamReceiverFilter
+	calls int32
+}
+
+func (f *continuingReceiverFilter) OnReceive(ctx context.Context, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) api.StreamFilterStatus {
+	atomic.AddInt32(&f.calls, 1)
+	return api.StreamFilterContinue
+}
+
+func (f *continuingReceiverFilter) OnDestroy() {}
+
+// continuingSenderFilter is the sender-chain counterpart of
+// continuingReceiverFilter.
+type continuingSenderFilter struct {
+	api.StreamSenderFilter
+	calls int32
+}
+
+func (f *continuingSenderFilter) Append(ctx context.Context, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) api.StreamFilterStatus {
+	atomic.AddInt32(&f.calls, 1)
+	return api.StreamFilterContinue
+}
+
+func (f *continuingSenderFilter) OnDestroy() {}
+
+// TestRunReceiverFilter_RunsAgainAfterCleanPass exercises the common
+// multi-phase, multi-call usage RunReceiverFilter/ValidatePhase are built
+// for (e.g. the data phase called after the header phase on the same
+// manager): a clean pass where every filter continues must rewind the index
+// so the next call runs the chain again instead of returning immediately
+// forever.
+func TestRunReceiverFilter_RunsAgainAfterCleanPass(t *testing.T) {
+	m := NewDefaultStreamFilterManagerImpl(StreamFilterManagerConfig{})
+
+	recv := &continuingReceiverFilter{}
+	m.AddStreamReceiverFilterWithPhase(NewStreamReceiverFilterWithPhaseImpl(recv, UndefinedFilterPhase))
+
+	ctx := context.Background()
+	m.RunReceiverFilter(ctx, UndefinedFilterPhase, nil, nil, nil, nil)
+	m.RunReceiverFilter(ctx, UndefinedFilterPhase, nil, nil, nil, nil)
+
+	if got := atomic.LoadInt32(&recv.calls); got != 2 {
+		t.Fatalf("receiver filter called %d times across two RunReceiverFilter calls, want 2", got)
+	}
+}
+
+// TestRunSenderFilter_RunsAgainAfterCleanPass is the sender-chain counterpart
+// of TestRunReceiverFilter_RunsAgainAfterCleanPass.
+func TestRunSenderFilter_RunsAgainAfterCleanPass(t *testing.T) {
+	m := NewDefaultStreamFilterManagerImpl(StreamFilterManagerConfig{})
+
+	send := &continuingSenderFilter{}
+	m.AddStreamSenderFilterWithPhase(NewStreamSenderFilterWithPhaseImpl(send, UndefinedFilterPhase))
+
+	ctx := context.Background()
+	m.RunSenderFilter(ctx, UndefinedFilterPhase, nil, nil, nil, nil)
+	m.RunSenderFilter(ctx, UndefinedFilterPhase, nil, nil, nil, nil)
+
+	if got := atomic.LoadInt32(&send.calls); got != 2 {
+		t.Fatalf("sender filter called %d times across two RunSenderFilter calls, want 2", got)
+	}
+}
+
+// TestRunReceiverFilter_LocalReplyStaysHaltedAcrossCalls makes sure the fix
+// for the clean-pass case doesn't regress the local-reply case: once a
+// filter sends a local reply, the chain must stay halted for the rest of
+// the stream, not just for the call that triggered it.
+func TestRunReceiverFilter_LocalReplyStaysHaltedAcrossCalls(t *testing.T) {
+	m := NewDefaultStreamFilterManagerImpl(StreamFilterManagerConfig{})
+
+	recv := &continuingReceiverFilter{}
+	m.AddStreamReceiverFilterWithPhase(NewStreamReceiverFilterWithPhaseImpl(recv, UndefinedFilterPhase))
+
+	m.sendLocalReply(&LocalReply{Status: 500})
+
+	ctx := context.Background()
+	m.RunReceiverFilter(ctx, UndefinedFilterPhase, nil, nil, nil, nil)
+	m.RunReceiverFilter(ctx, UndefinedFilterPhase, nil, nil, nil, nil)
+
+	if got := atomic.LoadInt32(&recv.calls); got != 0 {
+		t.Fatalf("receiver filter called %d times after a local reply, want 0", got)
+	}
+}
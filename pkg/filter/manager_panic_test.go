@@ -0,0 +1,131 @@
+package filter
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+// panickyReceiverFilter panics on every OnReceive call and counts how many
+// times OnDestroy runs, so tests can catch a double-destroy regression.
+type panickyReceiverFilter struct {
+	api.StreamReceiverFilter
+	destroyed int32
+}
+
+func (f *panickyReceiverFilter) OnReceive(ctx context.Context, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) api.StreamFilterStatus {
+	panic("boom")
+}
+
+func (f *panickyReceiverFilter) OnDestroy() {
+	atomic.AddInt32(&f.destroyed, 1)
+}
+
+// countingReceiverFilter records how many times OnReceive and OnDestroy run,
+// so tests can tell whether the chain reached it after the filter before it
+// panicked.
+type countingReceiverFilter struct {
+	api.StreamReceiverFilter
+	calls     int32
+	destroyed int32
+}
+
+func (f *countingReceiverFilter) OnReceive(ctx context.Context, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) api.StreamFilterStatus {
+	atomic.AddInt32(&f.calls, 1)
+	return api.StreamFilterContinue
+}
+
+func (f *countingReceiverFilter) OnDestroy() {
+	atomic.AddInt32(&f.destroyed, 1)
+}
+
+func TestRunReceiverFilter_PanicHandlerMatrix(t *testing.T) {
+	cases := []struct {
+		name           string
+		filterHandler  PanicHandler
+		managerHandler PanicHandler
+		wantLocalReply bool
+		wantNextRan    bool
+	}{
+		{
+			name:           "no handler configured falls back to DefaultPanicHandler",
+			wantLocalReply: true,
+			wantNextRan:    false,
+		},
+		{
+			name: "per-filter handler continues the chain",
+			filterHandler: func(ctx context.Context, name string, phase api.FilterPhase, recovered interface{}) (StreamFilterChainStatus, *LocalReply) {
+				return StreamFilterChainContinue, nil
+			},
+			wantLocalReply: false,
+			wantNextRan:    true,
+		},
+		{
+			name: "manager default handler is used when the filter has none",
+			managerHandler: func(ctx context.Context, name string, phase api.FilterPhase, recovered interface{}) (StreamFilterChainStatus, *LocalReply) {
+				return StreamFilterChainStop, nil
+			},
+			wantLocalReply: false,
+			wantNextRan:    false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewDefaultStreamFilterManagerImpl(StreamFilterManagerConfig{})
+			if tc.managerHandler != nil {
+				m.SetDefaultPanicHandler(tc.managerHandler)
+			}
+
+			var opts []FilterWithPhaseOption
+			if tc.filterHandler != nil {
+				opts = append(opts, WithPanicHandler(tc.filterHandler))
+			}
+			bad := &panickyReceiverFilter{}
+			m.AddStreamReceiverFilterWithPhase(NewStreamReceiverFilterWithPhaseImpl(bad, UndefinedFilterPhase, opts...))
+
+			next := &countingReceiverFilter{}
+			m.AddStreamReceiverFilterWithPhase(NewStreamReceiverFilterWithPhaseImpl(next, UndefinedFilterPhase))
+
+			m.RunReceiverFilter(context.Background(), UndefinedFilterPhase, nil, nil, nil, nil)
+
+			if got := m.LocalReply() != nil; got != tc.wantLocalReply {
+				t.Fatalf("LocalReply() != nil = %v, want %v", got, tc.wantLocalReply)
+			}
+			if got := atomic.LoadInt32(&next.calls) > 0; got != tc.wantNextRan {
+				t.Fatalf("next filter ran = %v, want %v", got, tc.wantNextRan)
+			}
+
+			// Whatever the panic policy decided, every filter must still get
+			// exactly one OnDestroy() call from the stream's bookend
+			// OnDestroy(), never two.
+			m.OnDestroy()
+			if got := atomic.LoadInt32(&bad.destroyed); got != 1 {
+				t.Fatalf("panicking filter destroyed %d times, want 1", got)
+			}
+			if got := atomic.LoadInt32(&next.destroyed); got != 1 {
+				t.Fatalf("downstream filter destroyed %d times, want 1", got)
+			}
+		})
+	}
+}
+
+func TestFilterPanicCounter_Registerable(t *testing.T) {
+	var got []string
+	SetFilterPanicCounter(FilterPanicCounterFunc(func(filter, phase string) {
+		got = append(got, filter+"/"+phase)
+	}))
+	defer SetFilterPanicCounter(newPanicCounter())
+
+	m := NewDefaultStreamFilterManagerImpl(StreamFilterManagerConfig{})
+	m.AddStreamReceiverFilterWithPhase(NewStreamReceiverFilterWithPhaseImpl(&panickyReceiverFilter{}, UndefinedFilterPhase))
+
+	m.RunReceiverFilter(context.Background(), UndefinedFilterPhase, nil, nil, nil, nil)
+
+	if len(got) != 1 {
+		t.Fatalf("registered FilterPanicCounter saw %d increments, want 1: %v", len(got), got)
+	}
+}